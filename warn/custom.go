@@ -0,0 +1,95 @@
+// Pluggable, user-defined warnings.
+
+package warn
+
+import (
+	"fmt"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// Warning is implemented by a user-defined, compiled-in lint check. Callers
+// register an implementation with RegisterWarning and it becomes runnable
+// under its own name exactly like a builtin warning.
+type Warning interface {
+	// Name returns the warning's identifier, e.g. "my-policy". It must be
+	// unique among both builtin and other registered warnings.
+	Name() string
+
+	// Check inspects f and returns the findings for this warning.
+	Check(f *build.File) []*LinterFinding
+}
+
+// FileWarningMap maps a whole-file warning's name to the function that
+// implements it. It's the actual dispatch table RunFileWarning and
+// AllWarnings use; RegisterWarning adds entries to it, so a registered
+// custom warning is indistinguishable from a builtin one once it's run.
+var FileWarningMap = map[string]func(*build.File, chan *LinterFinding){
+	"constant-glob":              constantGlobWarning,
+	"native-in-build-files":      nativeInBuildFilesWarning,
+	"native-package":             nativePackageWarning,
+	"duplicated-name":            duplicatedNameWarning,
+	"args-kwargs-in-build-files": argsKwargsInBuildFilesWarning,
+	"print":                      printWarning,
+	"unreachable-after-fail":     unreachableAfterFailWarning,
+	"overlapping-glob":           overlappingGlobWarning,
+}
+
+// AllWarnings lists the name of every warning known to the package, builtin
+// or registered via RegisterWarning, in no particular order.
+func AllWarnings() []string {
+	names := make([]string, 0, len(FileWarningMap))
+	for name := range FileWarningMap {
+		names = append(names, name)
+	}
+	return names
+}
+
+// DisabledWarning reports whether name should be skipped given the set of
+// warnings a caller explicitly enabled (e.g. via -warnings). A nil or empty
+// enabled set means nothing has been restricted, so nothing is disabled.
+func DisabledWarning(name string, enabled map[string]bool) bool {
+	if len(enabled) == 0 {
+		return false
+	}
+	return !enabled[name]
+}
+
+// RegisterWarning compiles w into FileWarningMap so it can be referred to by
+// name alongside the builtin warnings, including by RunFileWarning and
+// AllWarnings. It's meant to be called from an init() function in a
+// downstream package that imports warn, or via RegisterWarningSpec for the
+// JSON policy format.
+//
+// RegisterWarning panics if w's name collides with an existing warning
+// (builtin or previously registered), since that's always a programming
+// mistake rather than something to recover from at run time.
+func RegisterWarning(w Warning) {
+	name := w.Name()
+	if _, ok := FileWarningMap[name]; ok {
+		panic(fmt.Sprintf("warn: a warning named %q is already registered", name))
+	}
+	FileWarningMap[name] = func(f *build.File, findings chan *LinterFinding) {
+		defer close(findings)
+		for _, finding := range w.Check(f) {
+			findings <- finding
+		}
+	}
+}
+
+// RunFileWarning runs the named warning (builtin or registered via
+// RegisterWarning) against f and returns its findings. It returns an error
+// if name isn't in FileWarningMap.
+func RunFileWarning(name string, f *build.File) ([]*LinterFinding, error) {
+	fn, ok := FileWarningMap[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown warning %q", name)
+	}
+	findings := make(chan *LinterFinding)
+	go fn(f, findings)
+	var result []*LinterFinding
+	for finding := range findings {
+		result = append(result, finding)
+	}
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package warn
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+type stubWarning struct {
+	name     string
+	findings []*LinterFinding
+}
+
+func (s stubWarning) Name() string { return s.name }
+
+func (s stubWarning) Check(f *build.File) []*LinterFinding { return s.findings }
+
+func TestRegisterWarningAndRun(t *testing.T) {
+	name := "test-only-stub-warning"
+	want := &LinterFinding{Message: "stub finding"}
+	RegisterWarning(stubWarning{name: name, findings: []*LinterFinding{want}})
+	defer delete(FileWarningMap, name)
+
+	found := false
+	for _, n := range AllWarnings() {
+		if n == name {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("AllWarnings() doesn't include registered warning %q", name)
+	}
+
+	findings, err := RunFileWarning(name, &build.File{})
+	if err != nil {
+		t.Fatalf("RunFileWarning(%q) returned error: %v", name, err)
+	}
+	if len(findings) != 1 || findings[0].Message != want.Message {
+		t.Errorf("RunFileWarning(%q) = %v, want a single finding %v", name, findings, want)
+	}
+}
+
+func TestRegisterWarningDuplicateName(t *testing.T) {
+	name := "test-only-duplicate-stub-warning"
+	RegisterWarning(stubWarning{name: name})
+	defer delete(FileWarningMap, name)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterWarning with a duplicate name %q did not panic", name)
+		}
+	}()
+	RegisterWarning(stubWarning{name: name})
+}
+
+func TestRunFileWarningUnknown(t *testing.T) {
+	if _, err := RunFileWarning("no-such-warning", &build.File{}); err == nil {
+		t.Error("RunFileWarning with an unregistered name returned no error")
+	}
+}
+
+func TestDisabledWarning(t *testing.T) {
+	if DisabledWarning("anything", nil) {
+		t.Error("DisabledWarning with no enabled set should disable nothing")
+	}
+	enabled := map[string]bool{"constant-glob": true}
+	if DisabledWarning("constant-glob", enabled) {
+		t.Error("DisabledWarning(\"constant-glob\", {constant-glob: true}) = true, want false")
+	}
+	if !DisabledWarning("print", enabled) {
+		t.Error("DisabledWarning(\"print\", {constant-glob: true}) = false, want true")
+	}
+}
@@ -0,0 +1,307 @@
+// Output formats for reporting (and optionally applying) lint findings.
+
+package warn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// Format selects how a lint run reports (and optionally applies) its
+// findings.
+type Format int
+
+const (
+	// FormatPrint reports findings as human-readable text. This is the
+	// default and matches the linter's long-standing behavior.
+	FormatPrint Format = iota
+	// FormatFix rewrites files in place with all auto-fixable findings
+	// applied.
+	FormatFix
+	// FormatDiff renders a unified diff of the auto-fixes without writing
+	// them to disk.
+	FormatDiff
+	// FormatPatchJSON emits a stable, machine-readable JSON array of the
+	// auto-fixable findings instead of applying or diffing them.
+	FormatPatchJSON
+)
+
+// FormatFromName parses the -mode flag value into a Format, returning an
+// error that lists the valid names if name isn't recognized.
+func FormatFromName(name string) (Format, error) {
+	switch name {
+	case "", "print":
+		return FormatPrint, nil
+	case "fix":
+		return FormatFix, nil
+	case "diff":
+		return FormatDiff, nil
+	case "patch-json":
+		return FormatPatchJSON, nil
+	}
+	return FormatPrint, fmt.Errorf("unknown format %q, must be one of print, fix, diff, patch-json", name)
+}
+
+// PatchEntry is one auto-fixable edit in a patch-json bundle: replace the
+// span [Start, End) with Replacement, or, if Delete is set, remove it
+// entirely (Replacement is empty in that case).
+type PatchEntry struct {
+	File        string         `json:"file"`
+	Warning     string         `json:"warning"`
+	Start       build.Position `json:"start"`
+	End         build.Position `json:"end"`
+	Delete      bool           `json:"delete,omitempty"`
+	Replacement string         `json:"replacement,omitempty"`
+	Message     string         `json:"message"`
+}
+
+// RenderPatchJSON renders the auto-fixable findings for file as a stable
+// JSON array suitable for consumption by CI bots and code-review
+// integrations. warningName is the name of the warning that produced
+// findings, e.g. "constant-glob". A finding with more than one replacement
+// (e.g. a fix that both edits one node and adds another) produces one
+// PatchEntry per replacement, each spanning only the node that replacement
+// addresses rather than the whole finding. A replacement with a nil New
+// (e.g. nativePackageWarning's zero-arg case) produces an entry with
+// Delete set instead of being dropped, so consumers still see the edit.
+func RenderPatchJSON(file string, warningName string, findings []*LinterFinding) ([]byte, error) {
+	var entries []PatchEntry
+	for _, finding := range findings {
+		for _, r := range finding.Replacement {
+			start, end := finding.Start, finding.End
+			if r.Old != nil {
+				start, end = (*r.Old).Span()
+			}
+			entry := PatchEntry{
+				File:    file,
+				Warning: warningName,
+				Start:   start,
+				End:     end,
+				Message: finding.Message,
+			}
+			if r.New == nil {
+				entry.Delete = true
+			} else {
+				entry.Replacement = formatReplacementNode(r.New)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if entries == nil {
+		entries = []PatchEntry{}
+	}
+	return json.MarshalIndent(entries, "", "  ")
+}
+
+// formatReplacementNode renders a replacement's New expression back to
+// source text, the way it would appear once applied.
+func formatReplacementNode(expr build.Expr) string {
+	f := &build.File{Stmt: []build.Expr{expr}}
+	return strings.TrimSuffix(string(build.Format(f)), "\n")
+}
+
+// RenderDiff returns a unified diff between the original contents of a file
+// and its contents after auto-fixes have been applied. The diff is computed
+// in-process (a plain LCS line diff) rather than by shelling out to an
+// external "diff" binary, since buildifier needs to run in minimal
+// environments -- including Windows -- where one may not be installed.
+func RenderDiff(filename string, before, after []byte) string {
+	beforeLines := splitLines(string(before))
+	afterLines := splitLines(string(after))
+	ops := diffLines(beforeLines, afterLines)
+	return formatUnifiedDiff(filename, ops)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffOpKind
+	line string
+}
+
+// diffLines computes a line-level edit script turning a into b, using the
+// longest common subsequence of the two line lists. This is O(len(a) *
+// len(b)), which is fine for the size of files buildifier deals with.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, b[j]})
+	}
+	return ops
+}
+
+// formatUnifiedDiff renders an edit script as a standard unified diff with
+// three lines of context around each run of changes, e.g. what `diff -u`
+// would produce.
+func formatUnifiedDiff(filename string, ops []diffOp) string {
+	const context = 3
+
+	type hunk struct {
+		start, end int // indices into ops, end exclusive
+	}
+
+	var hunks []hunk
+	for i, op := range ops {
+		if op.kind == diffEqual {
+			continue
+		}
+		start := i
+		for k := 1; k <= context && start > 0; k++ {
+			if ops[start-1].kind != diffEqual {
+				break
+			}
+			start--
+		}
+		// Merge with the previous hunk when its trailing context would
+		// touch or overlap this one's leading context.
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end+context {
+			hunks[len(hunks)-1].end = i + 1
+			continue
+		}
+		hunks = append(hunks, hunk{start, i + 1})
+	}
+	// Extend each hunk's end by up to `context` trailing equal lines.
+	for h := range hunks {
+		end := hunks[h].end
+		for k := 0; k < context && end < len(ops); k++ {
+			if ops[end].kind != diffEqual {
+				break
+			}
+			end++
+		}
+		hunks[h].end = end
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", filename, filename)
+	beforeLine, afterLine := 1, 1
+	opLineNumbers := make([][2]int, len(ops)) // (before, after) line number for each op
+	for idx, op := range ops {
+		opLineNumbers[idx] = [2]int{beforeLine, afterLine}
+		switch op.kind {
+		case diffEqual:
+			beforeLine++
+			afterLine++
+		case diffDelete:
+			beforeLine++
+		case diffInsert:
+			afterLine++
+		}
+	}
+
+	for _, h := range hunks {
+		beforeCount, afterCount := 0, 0
+		for _, op := range ops[h.start:h.end] {
+			switch op.kind {
+			case diffEqual:
+				beforeCount++
+				afterCount++
+			case diffDelete:
+				beforeCount++
+			case diffInsert:
+				afterCount++
+			}
+		}
+		beforeStart, afterStart := opLineNumbers[h.start][0], opLineNumbers[h.start][1]
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", beforeStart, beforeCount, afterStart, afterCount)
+		for _, op := range ops[h.start:h.end] {
+			switch op.kind {
+			case diffEqual:
+				buf.WriteString(" ")
+			case diffDelete:
+				buf.WriteString("-")
+			case diffInsert:
+				buf.WriteString("+")
+			}
+			line := op.line
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			buf.WriteString(line)
+		}
+	}
+	return buf.String()
+}
+
+// Emit renders a lint run's result for format, given the file's original
+// contents, the fixed contents produced by applying findings' replacements,
+// and the findings themselves. It's the single dispatch point a CLI's
+// -mode flag should route through once one exists; see FormatFromName for
+// parsing that flag's value.
+func Emit(format Format, filename string, original, fixed []byte, warningName string, findings []*LinterFinding) ([]byte, error) {
+	switch format {
+	case FormatPrint:
+		var buf bytes.Buffer
+		for _, finding := range findings {
+			fmt.Fprintf(&buf, "%s:%d: %s\n", filename, finding.Start.Line, finding.Message)
+		}
+		return buf.Bytes(), nil
+	case FormatFix:
+		return fixed, nil
+	case FormatDiff:
+		return []byte(RenderDiff(filename, original, fixed)), nil
+	case FormatPatchJSON:
+		return RenderPatchJSON(filename, warningName, findings)
+	}
+	return nil, fmt.Errorf("unknown format %v", format)
+}
@@ -0,0 +1,162 @@
+package warn
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestFormatFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want Format
+		ok   bool
+	}{
+		{"", FormatPrint, true},
+		{"print", FormatPrint, true},
+		{"fix", FormatFix, true},
+		{"diff", FormatDiff, true},
+		{"patch-json", FormatPatchJSON, true},
+		{"bogus", FormatPrint, false},
+	}
+	for _, tt := range tests {
+		got, err := FormatFromName(tt.name)
+		if (err == nil) != tt.ok {
+			t.Errorf("FormatFromName(%q) error = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+		if got != tt.want {
+			t.Errorf("FormatFromName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	before := "a\nb\nc\n"
+	after := "a\nx\nc\n"
+	diff := RenderDiff("BUILD", []byte(before), []byte(after))
+
+	for _, want := range []string{"--- BUILD", "+++ BUILD", "-b", "+x"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("RenderDiff output missing %q:\n%s", want, diff)
+		}
+	}
+}
+
+func TestRenderDiffNoChange(t *testing.T) {
+	same := "a\nb\n"
+	diff := RenderDiff("BUILD", []byte(same), []byte(same))
+	if diff != "" {
+		t.Errorf("RenderDiff with no changes = %q, want empty", diff)
+	}
+}
+
+func TestRenderPatchJSON(t *testing.T) {
+	finding := &LinterFinding{
+		Message: "remove this",
+		Start:   build.Position{Line: 1},
+		End:     build.Position{Line: 1},
+		Replacement: []LinterReplacement{
+			{New: &build.StringExpr{Value: "replacement.go"}},
+		},
+	}
+
+	data, err := RenderPatchJSON("BUILD", "constant-glob", []*LinterFinding{finding})
+	if err != nil {
+		t.Fatalf("RenderPatchJSON: %v", err)
+	}
+
+	var entries []PatchEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("unmarshaling patch-json output: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1: %s", len(entries), data)
+	}
+	e := entries[0]
+	if e.File != "BUILD" || e.Warning != "constant-glob" || e.Message != "remove this" {
+		t.Errorf("entry = %+v, want file/warning/message to be passed through", e)
+	}
+	if !strings.Contains(e.Replacement, "replacement.go") {
+		t.Errorf("entry.Replacement = %q, want it to contain the replacement text", e.Replacement)
+	}
+
+	// The field names must match what the request specifies.
+	for _, field := range []string{`"file"`, `"warning"`, `"start"`, `"end"`, `"replacement"`, `"message"`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("patch-json output missing field %s:\n%s", field, data)
+		}
+	}
+}
+
+func TestRenderPatchJSONUsesPerReplacementSpan(t *testing.T) {
+	f, err := build.ParseBuild("BUILD", []byte(`x = glob(["a.go", "b.go"])`))
+	if err != nil {
+		t.Fatalf("ParseBuild: %v", err)
+	}
+	var list *build.ListExpr
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		if l, ok := expr.(*build.ListExpr); ok && list == nil {
+			list = l
+		}
+	})
+	if list == nil || len(list.List) != 2 {
+		t.Fatalf("failed to find the glob's pattern list in the parsed file")
+	}
+	a, b := list.List[0], list.List[1]
+
+	// A single finding with two replacements addressing two different
+	// nodes, the way constantGlobWarning's sibling-list fix does: each
+	// entry must report its own node's position, not the finding's.
+	finding := &LinterFinding{
+		Message: "two edits",
+		Start:   build.Position{Line: 1},
+		End:     build.Position{Line: 1},
+		Replacement: []LinterReplacement{
+			{Old: &a, New: &build.StringExpr{Value: "aa.go"}},
+			{Old: &b, New: &build.StringExpr{Value: "bb.go"}},
+		},
+	}
+
+	data, err := RenderPatchJSON("BUILD", "constant-glob", []*LinterFinding{finding})
+	if err != nil {
+		t.Fatalf("RenderPatchJSON: %v", err)
+	}
+	var entries []PatchEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("unmarshaling patch-json output: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %s", len(entries), data)
+	}
+	if entries[0].Start == entries[1].Start {
+		t.Errorf("both entries report the same position %+v, want each replacement's own node span", entries[0].Start)
+	}
+}
+
+func TestRenderPatchJSONRepresentsDeletions(t *testing.T) {
+	finding := &LinterFinding{
+		Message:     "delete this",
+		Start:       build.Position{Line: 3},
+		End:         build.Position{Line: 3},
+		Replacement: []LinterReplacement{{New: nil}},
+	}
+	data, err := RenderPatchJSON("test.bzl", "native-package", []*LinterFinding{finding})
+	if err != nil {
+		t.Fatalf("RenderPatchJSON: %v", err)
+	}
+	var entries []PatchEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("unmarshaling patch-json output: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries for a pending-deletion finding, want 1: %s", len(entries), data)
+	}
+	if !entries[0].Delete || entries[0].Replacement != "" {
+		t.Errorf("entry = %+v, want Delete = true and an empty Replacement", entries[0])
+	}
+	if !strings.Contains(string(data), `"delete"`) {
+		t.Errorf("patch-json output missing \"delete\" field:\n%s", data)
+	}
+}
@@ -0,0 +1,177 @@
+package warn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// PolicyRule is a single declarative "rules of this kind must look like
+// this" check, as parsed from a policy JSON file. It's compiled into a
+// Warning by LoadPolicyWarning without the user having to write any Go.
+//
+// A rule reads as: every instance of Kind in a package whose name matches
+// Package must set Attr to Value.
+type PolicyRule struct {
+	// Kind is the rule kind to match, e.g. "go_library". Empty matches any
+	// kind.
+	Kind string `json:"kind"`
+
+	// Package is a glob matched against the package's path (the directory
+	// containing the BUILD file), using path.Match syntax plus Bazel's "..."
+	// wildcard, which matches across any number of path segments (including
+	// zero) rather than stopping at the next "/" the way "*" does. Empty
+	// matches every package.
+	Package string `json:"package"`
+
+	// Attr is the attribute that must be present on matching rules.
+	Attr string `json:"attr"`
+
+	// Value is the attribute's required string value. For a list-valued
+	// attribute (e.g. visibility), it's enough for Value to appear anywhere
+	// in the list.
+	Value string `json:"value"`
+
+	// Message, if set, overrides the default finding message.
+	Message string `json:"message"`
+}
+
+// policyConfig is the top-level shape of a policy JSON file.
+type policyConfig struct {
+	Name  string       `json:"name"`
+	Rules []PolicyRule `json:"rules"`
+}
+
+// policyWarning is a Warning compiled from a policyConfig. It implements the
+// simple "rule kind X in package matching pattern P must have attribute
+// Y=Z" shape checks described by PolicyRule.
+type policyWarning struct {
+	name  string
+	rules []PolicyRule
+}
+
+// Name implements Warning.
+func (p *policyWarning) Name() string {
+	return p.name
+}
+
+// Check implements Warning.
+func (p *policyWarning) Check(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	pkg := path.Dir(f.Path)
+
+	for _, rule := range p.rules {
+		if rule.Package != "" && !packageMatches(rule.Package, pkg) {
+			continue
+		}
+		for _, r := range f.Rules(rule.Kind) {
+			attr := r.Attr(rule.Attr)
+			if attrHasValue(attr, rule.Value) {
+				continue
+			}
+			message := rule.Message
+			if message == "" {
+				message = fmt.Sprintf("%s %q must set %s=%q (policy %q).", r.Kind(), r.Name(), rule.Attr, rule.Value, p.name)
+			}
+			findings = append(findings, makeLinterFinding(r.Call, message))
+		}
+	}
+	return findings
+}
+
+// attrHasValue reports whether attr, a string- or list-of-strings-valued
+// attribute expression, is or contains value. A string attribute must equal
+// value exactly; a list attribute must contain it as one of its elements.
+func attrHasValue(attr build.Expr, value string) bool {
+	switch v := attr.(type) {
+	case *build.StringExpr:
+		return v.Value == value
+	case *build.ListExpr:
+		for _, e := range v.List {
+			if str, ok := e.(*build.StringExpr); ok && str.Value == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// packageMatches reports whether pkg matches pattern, using path.Match
+// segment syntax with one addition: a "..." segment matches across any
+// number of path segments, including zero, the way it does as a Bazel
+// package-path wildcard (unlike "*", which stops at the next "/").
+func packageMatches(pattern, pkg string) bool {
+	return packageSegsMatch(strings.Split(pattern, "/"), strings.Split(pkg, "/"))
+}
+
+func packageSegsMatch(pat, pkg []string) bool {
+	if len(pat) == 0 {
+		return len(pkg) == 0
+	}
+	if pat[0] == "..." {
+		if packageSegsMatch(pat[1:], pkg) {
+			return true
+		}
+		return len(pkg) > 0 && packageSegsMatch(pat, pkg[1:])
+	}
+	if len(pkg) == 0 {
+		return false
+	}
+	matched, err := path.Match(pat[0], pkg[0])
+	return err == nil && matched && packageSegsMatch(pat[1:], pkg[1:])
+}
+
+// LoadPolicyWarning parses a JSON policy file and compiles it into a Warning
+// ready to pass to RegisterWarning. The file has the shape:
+//
+//	{
+//	  "name": "my_policy",
+//	  "rules": [
+//	    {"kind": "go_library", "package": "internal/...", "attr": "visibility", "value": "//internal:__subpackages__"}
+//	  ]
+//	}
+func LoadPolicyWarning(data []byte) (Warning, error) {
+	var cfg policyConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing policy: %w", err)
+	}
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("policy is missing a \"name\"")
+	}
+	for i, rule := range cfg.Rules {
+		if rule.Attr == "" {
+			return nil, fmt.Errorf("policy %q: rule %d is missing \"attr\"", cfg.Name, i)
+		}
+	}
+	return &policyWarning{name: cfg.Name, rules: cfg.Rules}, nil
+}
+
+// RegisterWarningSpec handles a single -warnings value of the form
+// "custom:path/to/policy.json": it loads the policy file at path and
+// registers the resulting Warning, after which it's runnable through
+// RunFileWarning and listed by AllWarnings like any builtin. Specs that
+// don't start with "custom:" are left to the caller, since those are
+// ordinary builtin warning names.
+//
+// The CLI flag parsing that would call this for each -warnings value isn't
+// part of this package and still needs to be wired up there.
+func RegisterWarningSpec(spec string) error {
+	if !strings.HasPrefix(spec, "custom:") {
+		return fmt.Errorf("not a custom warning spec: %q", spec)
+	}
+	path := strings.TrimPrefix(spec, "custom:")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading policy %q: %w", path, err)
+	}
+	w, err := LoadPolicyWarning(data)
+	if err != nil {
+		return fmt.Errorf("loading policy %q: %w", path, err)
+	}
+	RegisterWarning(w)
+	return nil
+}
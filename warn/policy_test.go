@@ -0,0 +1,141 @@
+package warn
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestLoadPolicyWarningMissingName(t *testing.T) {
+	if _, err := LoadPolicyWarning([]byte(`{"rules": []}`)); err == nil {
+		t.Error("LoadPolicyWarning with no \"name\" returned no error")
+	}
+}
+
+func TestLoadPolicyWarningMissingAttr(t *testing.T) {
+	data := []byte(`{"name": "p", "rules": [{"kind": "go_library", "value": "x"}]}`)
+	if _, err := LoadPolicyWarning(data); err == nil {
+		t.Error("LoadPolicyWarning with a rule missing \"attr\" returned no error")
+	}
+}
+
+func TestPolicyWarningCheck(t *testing.T) {
+	data := []byte(`{
+		"name": "visibility_policy",
+		"rules": [
+			{"kind": "go_library", "attr": "visibility", "value": "//visibility:public"}
+		]
+	}`)
+	w, err := LoadPolicyWarning(data)
+	if err != nil {
+		t.Fatalf("LoadPolicyWarning: %v", err)
+	}
+
+	f, err := build.ParseBuild("BUILD", []byte(`
+go_library(name = "good", visibility = ["//visibility:public"])
+go_library(name = "bad", visibility = ["//visibility:private"])
+`))
+	if err != nil {
+		t.Fatalf("ParseBuild: %v", err)
+	}
+
+	findings := w.Check(f)
+	if len(findings) != 1 {
+		t.Fatalf("Check() returned %d findings, want 1: %v", len(findings), findings)
+	}
+}
+
+func TestRegisterWarningSpecEndToEnd(t *testing.T) {
+	name := "test-only-spec-policy"
+	path := filepath.Join(t.TempDir(), "policy.json")
+	data := []byte(`{
+		"name": "` + name + `",
+		"rules": [
+			{"kind": "go_library", "attr": "visibility", "value": "//visibility:public"}
+		]
+	}`)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := RegisterWarningSpec("custom:" + path); err != nil {
+		t.Fatalf("RegisterWarningSpec: %v", err)
+	}
+	defer delete(FileWarningMap, name)
+
+	f, err := build.ParseBuild("BUILD", []byte(`
+go_library(name = "bad", visibility = ["//visibility:private"])
+`))
+	if err != nil {
+		t.Fatalf("ParseBuild: %v", err)
+	}
+
+	findings, err := RunFileWarning(name, f)
+	if err != nil {
+		t.Fatalf("RunFileWarning(%q): %v", name, err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("RunFileWarning(%q) via a spec loaded by RegisterWarningSpec returned %d findings, want 1: %v", name, len(findings), findings)
+	}
+}
+
+func TestRegisterWarningSpecNotCustom(t *testing.T) {
+	if err := RegisterWarningSpec("print"); err == nil {
+		t.Error(`RegisterWarningSpec("print") returned no error, want one for a spec that doesn't start with "custom:"`)
+	}
+}
+
+func TestRegisterWarningSpecFileNotFound(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	if err := RegisterWarningSpec("custom:" + path); err == nil {
+		t.Error("RegisterWarningSpec with a nonexistent policy file returned no error")
+	}
+}
+
+func TestPackageMatches(t *testing.T) {
+	tests := []struct {
+		pattern, pkg string
+		want         bool
+	}{
+		{"internal/...", "internal", true},
+		{"internal/...", "internal/foo", true},
+		{"internal/...", "internal/foo/bar", true},
+		{"internal/...", "other", false},
+		{"internal/*", "internal/foo", true},
+		{"internal/*", "internal/foo/bar", false},
+		{"...", "a/b/c", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		if got := packageMatches(tt.pattern, tt.pkg); got != tt.want {
+			t.Errorf("packageMatches(%q, %q) = %v, want %v", tt.pattern, tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyWarningCheckRecursivePackageGlob(t *testing.T) {
+	data := []byte(`{
+		"name": "visibility_policy",
+		"rules": [
+			{"kind": "go_library", "package": "internal/...", "attr": "visibility", "value": "//visibility:public"}
+		]
+	}`)
+	w, err := LoadPolicyWarning(data)
+	if err != nil {
+		t.Fatalf("LoadPolicyWarning: %v", err)
+	}
+
+	f, err := build.ParseBuild("internal/foo/BUILD", []byte(`
+go_library(name = "bad", visibility = ["//visibility:private"])
+`))
+	if err != nil {
+		t.Fatalf("ParseBuild: %v", err)
+	}
+
+	findings := w.Check(f)
+	if len(findings) != 1 {
+		t.Fatalf("Check() on a subpackage of the \"internal/...\" pattern returned %d findings, want 1: %v", len(findings), findings)
+	}
+}
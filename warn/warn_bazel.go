@@ -16,8 +16,8 @@ func constantGlobWarning(f *build.File, findings chan *LinterFinding) {
 		return
 	}
 
-	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
-		call, ok := expr.(*build.CallExpr)
+	build.WalkPointers(f, func(expr *build.Expr, stack []build.Expr) {
+		call, ok := (*expr).(*build.CallExpr)
 		if !ok || len(call.List) == 0 {
 			return
 		}
@@ -29,21 +29,71 @@ func constantGlobWarning(f *build.File, findings chan *LinterFinding) {
 		if !ok {
 			return
 		}
-		for _, expr := range patterns.List {
-			str, ok := expr.(*build.StringExpr)
+		for i, e := range patterns.List {
+			str, ok := e.(*build.StringExpr)
 			if !ok {
 				continue
 			}
-			if !strings.Contains(str.Value, "*") {
-				message := fmt.Sprintf(
-					`Glob pattern %q has no wildcard ('*'). Constant patterns can be error-prone, move the file outside the glob.`, str.Value)
-				findings <- makeLinterFinding(expr, message)
-				return // at most one warning per glob
+			if strings.Contains(str.Value, "*") {
+				continue
+			}
+			message := fmt.Sprintf(
+				`Glob pattern %q has no wildcard ('*'). Constant patterns can be error-prone, move the file outside the glob.`, str.Value)
+			finding := makeLinterFinding(e, message)
+
+			// Offer to remove the constant pattern from the glob...
+			withoutElement := make([]build.Expr, 0, len(patterns.List)-1)
+			withoutElement = append(withoutElement, patterns.List[:i]...)
+			withoutElement = append(withoutElement, patterns.List[i+1:]...)
+			fixedPatterns := *patterns
+			fixedPatterns.List = withoutElement
+			finding.Replacement = append(finding.Replacement, LinterReplacement{&call.List[0], &fixedPatterns})
+
+			// ...and, if this glob feeds a `srcs = glob([...]) + [...]`
+			// style expression, append it to that literal list instead of
+			// dropping it on the floor.
+			if slot := findGlobSiblingListSlot(stack); slot != nil {
+				sibling := (*slot).(*build.ListExpr)
+				fixedSibling := *sibling
+				fixedSibling.List = append(append([]build.Expr{}, sibling.List...), &build.StringExpr{Value: str.Value})
+				finding.Replacement = append(finding.Replacement, LinterReplacement{slot, &fixedSibling})
 			}
+
+			findings <- finding
+			return // at most one warning per glob
 		}
 	})
 }
 
+// findGlobSiblingListSlot looks for an enclosing `srcs = glob([...]) + [...]`
+// assignment and, if found, returns the address of the literal list operand
+// so constantGlobWarning can append a pattern it's removing from the glob to
+// it instead of just deleting it.
+func findGlobSiblingListSlot(stack []build.Expr) *build.Expr {
+	for i := len(stack) - 1; i >= 0; i-- {
+		assign, ok := stack[i].(*build.AssignExpr)
+		if !ok {
+			continue
+		}
+		ident, ok := assign.LHS.(*build.Ident)
+		if !ok || ident.Name != "srcs" {
+			continue
+		}
+		bin, ok := assign.RHS.(*build.BinaryExpr)
+		if !ok || bin.Op != "+" {
+			return nil
+		}
+		if _, ok := bin.Y.(*build.ListExpr); ok {
+			return &bin.Y
+		}
+		if _, ok := bin.X.(*build.ListExpr); ok {
+			return &bin.X
+		}
+		return nil
+	}
+	return nil
+}
+
 func nativeInBuildFilesWarning(f *build.File, findings chan *LinterFinding) {
 	defer close(findings)
 	if f.Type != build.TypeBuild {
@@ -73,9 +123,9 @@ func nativePackageWarning(f *build.File, findings chan *LinterFinding) {
 		return
 	}
 
-	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+	build.WalkPointers(f, func(expr *build.Expr, stack []build.Expr) {
 		// Search for `native.package()` nodes
-		call, ok := expr.(*build.CallExpr)
+		call, ok := (*expr).(*build.CallExpr)
 		if !ok {
 			return
 		}
@@ -88,7 +138,17 @@ func nativePackageWarning(f *build.File, findings chan *LinterFinding) {
 			return
 		}
 
-		findings <- makeLinterFinding(call, `"native.package()" shouldn't be used in .bzl files.`)
+		finding := makeLinterFinding(call, `"native.package()" shouldn't be used in .bzl files.`)
+		if len(call.List) == 0 {
+			// No non-default arguments: the call can simply be deleted.
+			// A nil New asks the fixer to remove the node at Old rather
+			// than replace it, which is what a whole-statement deletion
+			// comes down to when Old addresses a slot in a statement list.
+			finding.Replacement = append(finding.Replacement, LinterReplacement{expr, nil})
+		} else {
+			finding.Message += ` It can't be auto-fixed because its arguments would need to become attributes of a package() call in a BUILD file instead.`
+		}
+		findings <- finding
 	})
 }
 
@@ -0,0 +1,93 @@
+package warn
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func parseBuild(t *testing.T, src string) *build.File {
+	t.Helper()
+	f, err := build.ParseBuild("BUILD", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseBuild: %v", err)
+	}
+	return f
+}
+
+func runConstantGlob(t *testing.T, src string) []*LinterFinding {
+	t.Helper()
+	f := parseBuild(t, src)
+	findings := make(chan *LinterFinding)
+	go constantGlobWarning(f, findings)
+	var result []*LinterFinding
+	for finding := range findings {
+		result = append(result, finding)
+	}
+	return result
+}
+
+func TestConstantGlobWarningNoSiblingList(t *testing.T) {
+	findings := runConstantGlob(t, `
+x = glob(["a.go", "*.py"])
+`)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findingMessages(findings))
+	}
+	if len(findings[0].Replacement) != 1 {
+		t.Errorf("got %d replacements, want 1 (just removing the constant pattern): %+v", len(findings[0].Replacement), findings[0].Replacement)
+	}
+}
+
+func TestConstantGlobWarningAppendsToSiblingList(t *testing.T) {
+	findings := runConstantGlob(t, `
+srcs = glob(["a.go", "*.py"]) + ["existing.go"]
+`)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findingMessages(findings))
+	}
+	if len(findings[0].Replacement) != 2 {
+		t.Fatalf("got %d replacements, want 2 (remove from glob, append to sibling list): %+v", len(findings[0].Replacement), findings[0].Replacement)
+	}
+}
+
+func runNativePackage(t *testing.T, src string) []*LinterFinding {
+	t.Helper()
+	f, err := build.ParseBzl("test.bzl", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseBzl: %v", err)
+	}
+	findings := make(chan *LinterFinding)
+	go nativePackageWarning(f, findings)
+	var result []*LinterFinding
+	for finding := range findings {
+		result = append(result, finding)
+	}
+	return result
+}
+
+func TestNativePackageWarningZeroArgsIsAutoFixable(t *testing.T) {
+	findings := runNativePackage(t, `
+def f():
+    native.package()
+`)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findingMessages(findings))
+	}
+	if len(findings[0].Replacement) != 1 || findings[0].Replacement[0].New != nil {
+		t.Errorf("replacement = %+v, want a single pending deletion (New == nil)", findings[0].Replacement)
+	}
+}
+
+func TestNativePackageWarningWithArgsIsNotAutoFixable(t *testing.T) {
+	findings := runNativePackage(t, `
+def f():
+    native.package(default_visibility = ["//visibility:public"])
+`)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findingMessages(findings))
+	}
+	if len(findings[0].Replacement) != 0 {
+		t.Errorf("got %d replacements, want 0: a call with arguments can't be auto-fixed", len(findings[0].Replacement))
+	}
+}
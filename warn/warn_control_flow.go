@@ -0,0 +1,85 @@
+// Control-flow-aware warnings for .bzl and macro bodies.
+
+package warn
+
+import (
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// terminates reports whether execution of stmts is guaranteed to leave the
+// enclosing block early, e.g. via a return, a call to fail(), or an
+// if/else where both branches terminate. It mirrors the recursion pattern
+// used by the return-without-value control-flow check: IfStmt requires both
+// branches to terminate, while ForStmt never propagates termination since
+// the loop body isn't guaranteed to run.
+//
+// A block terminates as soon as any one of its statements does, even if
+// further (unreachable) statements follow it -- those are covered
+// separately by checkUnreachableStatements.
+func terminates(stmts []build.Expr) bool {
+	for _, stmt := range stmts {
+		if stmtTerminates(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// stmtTerminates reports whether a single statement always terminates the
+// block it's in.
+func stmtTerminates(stmt build.Expr) bool {
+	switch stmt := stmt.(type) {
+	case *build.ReturnStmt:
+		return true
+	case *build.BranchStmt:
+		// An unconditional `continue` or `break` terminates the remainder
+		// of the current block just like a return does; `pass` doesn't.
+		return stmt.Token == "continue" || stmt.Token == "break"
+	case *build.IfStmt:
+		return terminates(stmt.True) && terminates(stmt.False)
+	case *build.CallExpr:
+		ident, ok := stmt.X.(*build.Ident)
+		return ok && ident.Name == "fail"
+	default:
+		return false
+	}
+}
+
+// unreachableAfterFailWarning flags statements that can never execute
+// because the statement before them always returns, fails, or otherwise
+// terminates the enclosing block.
+func unreachableAfterFailWarning(f *build.File, findings chan *LinterFinding) {
+	defer close(findings)
+	if f.Type == build.TypeDefault {
+		// Only applicable to Bazel files
+		return
+	}
+
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		def, ok := expr.(*build.DefStmt)
+		if !ok {
+			return
+		}
+		checkUnreachableStatements(def.Body, findings)
+	})
+}
+
+// checkUnreachableStatements walks stmts (a function, if-branch, or loop
+// body) and emits a finding at the first statement that follows one that
+// always terminates, recursing into nested ifs and loops along the way.
+func checkUnreachableStatements(stmts []build.Expr, findings chan *LinterFinding) {
+	for i, stmt := range stmts {
+		if i > 0 && stmtTerminates(stmts[i-1]) {
+			findings <- makeLinterFinding(stmt,
+				`This statement is unreachable: the statement before it always returns, fails, or otherwise terminates the block.`)
+			return
+		}
+		switch stmt := stmt.(type) {
+		case *build.IfStmt:
+			checkUnreachableStatements(stmt.True, findings)
+			checkUnreachableStatements(stmt.False, findings)
+		case *build.ForStmt:
+			checkUnreachableStatements(stmt.Body, findings)
+		}
+	}
+}
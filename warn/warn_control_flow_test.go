@@ -0,0 +1,126 @@
+package warn
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func parseBzl(t *testing.T, src string) *build.File {
+	t.Helper()
+	f, err := build.ParseBzl("test.bzl", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseBzl: %v", err)
+	}
+	return f
+}
+
+func findingMessages(findings []*LinterFinding) []string {
+	var messages []string
+	for _, f := range findings {
+		messages = append(messages, f.Message)
+	}
+	return messages
+}
+
+func runUnreachableAfterFail(t *testing.T, src string) []*LinterFinding {
+	t.Helper()
+	f := parseBzl(t, src)
+	findings := make(chan *LinterFinding)
+	go unreachableAfterFailWarning(f, findings)
+	var result []*LinterFinding
+	for finding := range findings {
+		result = append(result, finding)
+	}
+	return result
+}
+
+func TestTerminatesIgnoresTrailingDeadCode(t *testing.T) {
+	// The block terminates because of the `return`, even though a
+	// (separately flagged) unreachable statement follows it.
+	f := parseBzl(t, `
+def f():
+    if True:
+        return 1
+        print("dead")
+`)
+	var def *build.DefStmt
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		if d, ok := expr.(*build.DefStmt); ok {
+			def = d
+		}
+	})
+	ifStmt := def.Body[0].(*build.IfStmt)
+	if !terminates(ifStmt.True) {
+		t.Error("terminates(True) = false, want true: a block terminates as soon as any statement in it does")
+	}
+}
+
+func TestUnreachableAfterFailWarning(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		want int
+	}{
+		{
+			desc: "simple unreachable after return",
+			src: `
+def f():
+    return 1
+    print("unreachable")
+`,
+			want: 1,
+		},
+		{
+			desc: "unreachable after fail",
+			src: `
+def f():
+    fail("no")
+    print("unreachable")
+`,
+			want: 1,
+		},
+		{
+			desc: "no unreachable code",
+			src: `
+def f():
+    print("a")
+    return 1
+`,
+			want: 0,
+		},
+		{
+			desc: "for loop body does not propagate termination",
+			src: `
+def f():
+    for x in y:
+        return x
+    print("reachable")
+`,
+			want: 0,
+		},
+		{
+			desc: "statement after if/else where both branches terminate (with trailing dead code in each arm)",
+			src: `
+def f():
+    if cond:
+        return 1
+        print("dead in true branch")
+    else:
+        fail("no")
+        print("dead in false branch")
+    print("unreachable after if/else")
+`,
+			want: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			findings := runUnreachableAfterFail(t, tt.src)
+			if len(findings) != tt.want {
+				t.Errorf("got %d findings %v, want %d", len(findings), findingMessages(findings), tt.want)
+			}
+		})
+	}
+}
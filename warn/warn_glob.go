@@ -0,0 +1,471 @@
+// Overlapping and shadowed glob pattern warning.
+
+package warn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// globToken identifies the kind of wildcard a non-exact, non-"**" segment
+// uses in its single wildcard slot.
+type globToken int
+
+const (
+	tokenStar  globToken = iota // '*': zero or more arbitrary characters
+	tokenAny                    // '?': exactly one arbitrary character
+	tokenClass                  // '[...]': exactly one character from a set
+)
+
+// charClass is a parsed "[...]" character class, using the same syntax as
+// path.Match: an optional leading '^' negates, and "lo-hi" pairs specify
+// ranges.
+type charClass struct {
+	negate bool
+	chars  map[rune]bool
+}
+
+func (c *charClass) matches(r rune) bool {
+	return c.chars[r] != c.negate
+}
+
+// subsumes reports whether every character other can match, c can also
+// match.
+func (c *charClass) subsumes(other *charClass) bool {
+	if c.negate == other.negate {
+		// Same polarity: c must include (for two positive classes) or be
+		// included by (for two negated ones, where a smaller exclusion set
+		// means a larger match set) other's set.
+		small, big := other.chars, c.chars
+		if c.negate {
+			small, big = c.chars, other.chars
+		}
+		for r := range small {
+			if !big[r] {
+				return false
+			}
+		}
+		return true
+	}
+	// One negated, one not: proving a subset relationship in general means
+	// reasoning about an unbounded character set; conservatively refuse to
+	// claim subsumption rather than risk a false positive.
+	return false
+}
+
+// overlaps reports whether some character could satisfy both c and other.
+// Negated classes are treated conservatively, since enumerating their
+// complement isn't worth it here: assume they can always find a common
+// character rather than risk claiming two patterns are disjoint when
+// they're not.
+func (c *charClass) overlaps(other *charClass) bool {
+	if c.negate || other.negate {
+		return true
+	}
+	for r := range c.chars {
+		if other.chars[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCharClass parses the contents of a "[...]" segment (without the
+// brackets).
+func parseCharClass(body string) (*charClass, bool) {
+	runes := []rune(body)
+	if len(runes) == 0 {
+		return nil, false
+	}
+	c := &charClass{chars: map[rune]bool{}}
+	i := 0
+	if runes[i] == '^' {
+		c.negate = true
+		i++
+	}
+	if i >= len(runes) {
+		return nil, false
+	}
+	for i < len(runes) {
+		if i+2 < len(runes) && runes[i+1] == '-' {
+			lo, hi := runes[i], runes[i+2]
+			if hi < lo {
+				return nil, false
+			}
+			for r := lo; r <= hi; r++ {
+				c.chars[r] = true
+			}
+			i += 3
+			continue
+		}
+		c.chars[runes[i]] = true
+		i++
+	}
+	return c, true
+}
+
+// globSegment is a single path segment (the part of a pattern between
+// slashes) broken down enough to reason about subsumption and overlap. At
+// most one wildcard ('*', '?', or a single "[...]" class) per segment is
+// understood; segments combining more than one, or using syntax this
+// checker doesn't recognize (e.g. backslash escapes), are marked
+// !analyzable and are simply skipped rather than risking a false positive.
+type globSegment struct {
+	doubleStar bool // the whole segment is "**"
+	analyzable bool
+	exact      bool // no wildcard at all: prefix is the full literal segment
+	token      globToken
+	class      *charClass // non-nil iff token == tokenClass
+	prefix     string
+	suffix     string
+}
+
+func parseGlobSegment(seg string) globSegment {
+	if seg == "**" {
+		return globSegment{doubleStar: true, analyzable: true}
+	}
+	if strings.ContainsRune(seg, '\\') {
+		// Escapes aren't supported by this checker; bail out rather than
+		// risk misinterpreting one.
+		return globSegment{}
+	}
+	star := strings.IndexByte(seg, '*')
+	any := strings.IndexByte(seg, '?')
+	class := strings.IndexByte(seg, '[')
+
+	switch {
+	case star < 0 && any < 0 && class < 0:
+		return globSegment{analyzable: true, exact: true, prefix: seg}
+
+	case star >= 0 && any < 0 && class < 0:
+		if strings.Count(seg, "*") > 1 {
+			return globSegment{}
+		}
+		return globSegment{analyzable: true, token: tokenStar, prefix: seg[:star], suffix: seg[star+1:]}
+
+	case any >= 0 && star < 0 && class < 0:
+		if strings.Count(seg, "?") > 1 {
+			return globSegment{}
+		}
+		return globSegment{analyzable: true, token: tokenAny, prefix: seg[:any], suffix: seg[any+1:]}
+
+	case class >= 0 && star < 0 && any < 0:
+		end := strings.IndexByte(seg[class:], ']')
+		if end < 0 {
+			return globSegment{}
+		}
+		end += class
+		if strings.ContainsAny(seg[:class], "*?[]") || strings.ContainsAny(seg[end+1:], "*?[]") {
+			return globSegment{}
+		}
+		cc, ok := parseCharClass(seg[class+1 : end])
+		if !ok {
+			return globSegment{}
+		}
+		return globSegment{analyzable: true, token: tokenClass, class: cc, prefix: seg[:class], suffix: seg[end+1:]}
+
+	default:
+		// More than one kind of wildcard in a single segment isn't
+		// supported; skip rather than risk a false positive.
+		return globSegment{}
+	}
+}
+
+// tokenCanProduce reports whether a's wildcard token on its own (i.e.
+// ignoring a's prefix/suffix) can produce the literal string s.
+func (a globSegment) tokenCanProduce(s string) bool {
+	switch a.token {
+	case tokenStar:
+		return true
+	case tokenAny:
+		return len([]rune(s)) == 1
+	default: // tokenClass
+		runes := []rune(s)
+		return len(runes) == 1 && a.class.matches(runes[0])
+	}
+}
+
+// tokenOverlapsLiteral reports whether a's wildcard, combined with its
+// fixed prefix/suffix, can match the literal string lit.
+func (a globSegment) tokenOverlapsLiteral(lit string) bool {
+	if !strings.HasPrefix(lit, a.prefix) || !strings.HasSuffix(lit, a.suffix) ||
+		len(lit) < len(a.prefix)+len(a.suffix) {
+		return false
+	}
+	return a.tokenCanProduce(lit[len(a.prefix) : len(lit)-len(a.suffix)])
+}
+
+// tokensShareAChar reports whether some single character could satisfy
+// both a's and b's wildcard token, assuming neither is tokenStar.
+func tokensShareAChar(a, b globSegment) bool {
+	if a.token == tokenAny || b.token == tokenAny {
+		return true // '?' matches any character, including any of the other's class
+	}
+	return a.class.overlaps(b.class)
+}
+
+// subsumes reports whether every string matched by b is also matched by a,
+// for two analyzable, non-"**" segments.
+func (a globSegment) subsumes(b globSegment) bool {
+	if a.exact {
+		return b.exact && a.prefix == b.prefix
+	}
+	if b.exact {
+		return a.tokenOverlapsLiteral(b.prefix)
+	}
+	if !strings.HasPrefix(b.prefix, a.prefix) || !strings.HasSuffix(b.suffix, a.suffix) {
+		return false
+	}
+	if a.token != tokenStar && (a.prefix != b.prefix || a.suffix != b.suffix) {
+		// a matches strings of one fixed length, with its wildcard at one
+		// fixed position, so b's fixed parts must line up with a's exactly.
+		return false
+	}
+	switch a.token {
+	case tokenStar:
+		// '*' matches any length, any characters, so it subsumes whatever
+		// b's (necessarily no less restrictive) wildcard can produce.
+		return true
+	case tokenAny:
+		// '?' matches any single character, so it subsumes b's wildcard as
+		// long as b also occupies exactly one character -- a variable-length
+		// run is something only '*' can produce.
+		return b.token == tokenAny || b.token == tokenClass
+	default: // tokenClass
+		return b.token == tokenClass && a.class.subsumes(b.class)
+	}
+}
+
+// overlaps reports whether a and b can both match at least one common
+// string, for two analyzable, non-"**" segments. For two wildcard segments
+// the answer errs towards true: segment lengths aren't compared, and two
+// non-'*' wildcards at different positions are assumed to overlap, so it
+// can report an overlap (e.g. "file?.go" and "[abc].go") where none exists.
+func (a globSegment) overlaps(b globSegment) bool {
+	if a.exact && b.exact {
+		return a.prefix == b.prefix
+	}
+	if a.exact {
+		return b.tokenOverlapsLiteral(a.prefix)
+	}
+	if b.exact {
+		return a.tokenOverlapsLiteral(b.prefix)
+	}
+	// Two wildcard segments: they overlap unless their fixed prefixes or
+	// suffixes are incompatible, or (when neither is '*' and both wildcards
+	// sit at the same position) their character sets share no common
+	// character.
+	shorterPrefix, longerPrefix := a.prefix, b.prefix
+	if len(shorterPrefix) > len(longerPrefix) {
+		shorterPrefix, longerPrefix = longerPrefix, shorterPrefix
+	}
+	if !strings.HasPrefix(longerPrefix, shorterPrefix) {
+		return false
+	}
+	shorterSuffix, longerSuffix := a.suffix, b.suffix
+	if len(shorterSuffix) > len(longerSuffix) {
+		shorterSuffix, longerSuffix = longerSuffix, shorterSuffix
+	}
+	if !strings.HasSuffix(longerSuffix, shorterSuffix) {
+		return false
+	}
+	if a.token == tokenStar || b.token == tokenStar || len(a.prefix) != len(b.prefix) {
+		return true
+	}
+	return tokensShareAChar(a, b)
+}
+
+// splitGlobPattern breaks a pattern into analyzable segments, returning ok
+// == false if the pattern contains a segment this checker doesn't
+// understand (so callers should skip it rather than risk a false positive).
+func splitGlobPattern(pattern string) (segs []globSegment, ok bool) {
+	for _, part := range strings.Split(pattern, "/") {
+		seg := parseGlobSegment(part)
+		if !seg.analyzable {
+			return nil, false
+		}
+		segs = append(segs, seg)
+	}
+	return segs, true
+}
+
+// patternSubsumes reports whether every path matched by b is also matched
+// by a, handling at most one "**" segment in each pattern.
+func patternSubsumes(a, b []globSegment) bool {
+	if len(a) == 0 {
+		return len(b) == 0
+	}
+	if a[0].doubleStar {
+		// "**" matches zero segments (try the rest of a against all of b)
+		// or consumes one segment of b and stays put.
+		if patternSubsumes(a[1:], b) {
+			return true
+		}
+		return len(b) > 0 && patternSubsumes(a, b[1:])
+	}
+	if len(b) == 0 || b[0].doubleStar {
+		// b can produce paths shorter, or of unbounded length, than a's
+		// remaining fixed segment -- not something we can prove here.
+		return false
+	}
+	return a[0].subsumes(b[0]) && patternSubsumes(a[1:], b[1:])
+}
+
+// patternOverlaps reports whether patterns a and b can both match at least
+// one common path, handling at most one "**" segment in each pattern.
+func patternOverlaps(a, b []globSegment) bool {
+	if len(a) == 0 || len(b) == 0 {
+		// "**" can match zero segments, so an empty pattern overlaps with
+		// anything that's either also empty or starts with "**".
+		rest := a
+		if len(a) == 0 {
+			rest = b
+		}
+		for _, s := range rest {
+			if !s.doubleStar {
+				return len(a) == 0 && len(b) == 0
+			}
+		}
+		return true
+	}
+	if a[0].doubleStar {
+		return patternOverlaps(a[1:], b) || patternOverlaps(a, b[1:])
+	}
+	if b[0].doubleStar {
+		return patternOverlaps(b, a)
+	}
+	return a[0].overlaps(b[0]) && patternOverlaps(a[1:], b[1:])
+}
+
+// globCall is a single glob(...) invocation's parsed include and exclude
+// string literals, keyed back to their AST nodes for reporting.
+type globCall struct {
+	includes []*build.StringExpr
+	excludes []*build.StringExpr
+}
+
+func parseGlobCall(call *build.CallExpr) (globCall, bool) {
+	var g globCall
+	for i, arg := range call.List {
+		name := ""
+		value := arg
+		if assign, ok := arg.(*build.AssignExpr); ok {
+			if ident, ok := assign.LHS.(*build.Ident); ok {
+				name = ident.Name
+			}
+			value = assign.RHS
+		} else if i < 2 {
+			// Positional arguments follow glob's signature: include, exclude.
+			name = []string{"include", "exclude"}[i]
+		}
+		list, ok := value.(*build.ListExpr)
+		if !ok {
+			continue
+		}
+		var dst *[]*build.StringExpr
+		switch name {
+		case "include":
+			dst = &g.includes
+		case "exclude":
+			dst = &g.excludes
+		default:
+			continue
+		}
+		for _, elem := range list.List {
+			str, ok := elem.(*build.StringExpr)
+			if !ok {
+				// Skip patterns with unresolved variable interpolation or
+				// other non-literal expressions.
+				continue
+			}
+			*dst = append(*dst, str)
+		}
+	}
+	return g, len(g.includes) > 0
+}
+
+// overlappingGlobWarning flags glob() calls where one include pattern is
+// entirely subsumed by another, or where an exclude pattern doesn't
+// actually exclude anything from the includes.
+func overlappingGlobWarning(f *build.File, findings chan *LinterFinding) {
+	defer close(findings)
+	if f.Type == build.TypeDefault {
+		// Only applicable to Bazel files
+		return
+	}
+
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		call, ok := expr.(*build.CallExpr)
+		if !ok {
+			return
+		}
+		ident, ok := call.X.(*build.Ident)
+		if !ok || ident.Name != "glob" {
+			return
+		}
+		g, ok := parseGlobCall(call)
+		if !ok {
+			return
+		}
+
+		parsed := make([][]globSegment, len(g.includes))
+		for i, str := range g.includes {
+			segs, ok := splitGlobPattern(str.Value)
+			if !ok {
+				continue
+			}
+			parsed[i] = segs
+		}
+
+		for i, a := range parsed {
+			if a == nil {
+				continue
+			}
+			for j, b := range parsed {
+				if i == j || b == nil || g.includes[i].Value == g.includes[j].Value {
+					continue
+				}
+				if patternSubsumes(a, b) {
+					findings <- makeLinterFinding(g.includes[j], fmt.Sprintf(
+						`Pattern %q makes %q redundant, since it already matches everything %q matches.`,
+						g.includes[i].Value, g.includes[j].Value, g.includes[j].Value))
+				}
+			}
+		}
+
+		// If any include couldn't be analyzed, we can't rule out that it's
+		// what an exclude is meant to match, so don't report any "exclude
+		// matches nothing" findings for this call at all: "couldn't prove
+		// it overlaps" is not the same as "doesn't exist".
+		allIncludesAnalyzable := true
+		for _, include := range parsed {
+			if include == nil {
+				allIncludesAnalyzable = false
+				break
+			}
+		}
+		if !allIncludesAnalyzable {
+			return
+		}
+
+		for _, exclude := range g.excludes {
+			excludeSegs, ok := splitGlobPattern(exclude.Value)
+			if !ok {
+				continue
+			}
+			matchesSomething := false
+			for _, include := range parsed {
+				if patternOverlaps(excludeSegs, include) {
+					matchesSomething = true
+					break
+				}
+			}
+			if !matchesSomething {
+				findings <- makeLinterFinding(exclude, fmt.Sprintf(
+					`Exclude %q does not match any include pattern and can be removed.`, exclude.Value))
+			}
+		}
+	})
+}
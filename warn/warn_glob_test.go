@@ -0,0 +1,139 @@
+package warn
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func splitOK(t *testing.T, pattern string) []globSegment {
+	t.Helper()
+	segs, ok := splitGlobPattern(pattern)
+	if !ok {
+		t.Fatalf("splitGlobPattern(%q) not analyzable", pattern)
+	}
+	return segs
+}
+
+func TestPatternSubsumes(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"src/**/*.go", "src/foo/*.go", true},
+		{"src/**/*.go", "src/foo/bar/*.go", true},
+		{"*.go", "foo.go", true},
+		{"*.go", "foo.py", false},
+		{"foo.go", "foo.go", true},
+		{"foo.go", "bar.go", false},
+		{"src/*.go", "src/other/*.go", false},
+		{"file?.go", "file1.go", true},
+		{"file?.go", "file12.go", false}, // '?' matches exactly one character
+		{"[abc].go", "a.go", true},
+		{"[abc].go", "d.go", false},
+		{"[abc].go", "[ab].go", true},  // a's class is a superset of b's
+		{"[ab].go", "[abc].go", false}, // a's class doesn't cover all of b's
+	}
+	for _, tt := range tests {
+		a := splitOK(t, tt.a)
+		b := splitOK(t, tt.b)
+		if got := patternSubsumes(a, b); got != tt.want {
+			t.Errorf("patternSubsumes(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPatternOverlaps(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"src/abc/foo.go", "src/abc/*.go", true},
+		{"src/other/*.cc", "src/abc/*.go", false},
+		{"src/*.go", "src/foo.go", true},
+		{"*.go", "*.py", false},
+		{"file?.go", "file1.go", true},
+		{"[abc].go", "[bcd].go", true},
+		{"[abc].go", "[def].go", false},
+		{"file?.go", "[abc].go", true}, // wildcards at different positions are assumed to overlap
+	}
+	for _, tt := range tests {
+		a := splitOK(t, tt.a)
+		b := splitOK(t, tt.b)
+		if got := patternOverlaps(a, b); got != tt.want {
+			t.Errorf("patternOverlaps(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func runOverlappingGlob(t *testing.T, src string) []*LinterFinding {
+	t.Helper()
+	f, err := build.ParseBuild("BUILD", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseBuild: %v", err)
+	}
+	findings := make(chan *LinterFinding)
+	go overlappingGlobWarning(f, findings)
+	var result []*LinterFinding
+	for finding := range findings {
+		result = append(result, finding)
+	}
+	return result
+}
+
+func TestOverlappingGlobWarningRedundantInclude(t *testing.T) {
+	findings := runOverlappingGlob(t, `
+x = glob(["src/**/*.go", "src/foo/*.go"])
+`)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findingMessages(findings))
+	}
+}
+
+func TestOverlappingGlobWarningUselessExclude(t *testing.T) {
+	findings := runOverlappingGlob(t, `
+x = glob(include = ["src/*.go"], exclude = ["src/other/*.cc"])
+`)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findingMessages(findings))
+	}
+}
+
+func TestOverlappingGlobWarningSkipsUnanalyzableIncludes(t *testing.T) {
+	// "src/a*b?c/*.go" mixes two kinds of wildcard in one segment, which
+	// this checker doesn't support, so it can't be analyzed. The exclude
+	// check must not claim the exclude matches nothing: it plausibly
+	// matches the pattern we can't reason about.
+	findings := runOverlappingGlob(t, `
+x = glob(include = ["src/a*b?c/*.go", "src/other/*.go"], exclude = ["src/abc/foo.go"])
+`)
+	if len(findings) != 0 {
+		t.Errorf("got %d findings, want 0 (should bail out when an include is unanalyzable): %v", len(findings), findingMessages(findings))
+	}
+}
+
+func TestOverlappingGlobWarningCharacterClass(t *testing.T) {
+	// "src/[ab]/*.go" and "src/a/*.go" overlap (class 'a' matches the
+	// literal), so the redundant literal include should be flagged.
+	findings := runOverlappingGlob(t, `
+x = glob(["src/[ab]/*.go", "src/a/*.go"])
+`)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findingMessages(findings))
+	}
+}
+
+func TestSplitGlobPatternCharacterClassAndAny(t *testing.T) {
+	if _, ok := splitGlobPattern("src/[abc]/*.go"); !ok {
+		t.Error(`splitGlobPattern("src/[abc]/*.go") not analyzable, want it to be`)
+	}
+	if _, ok := splitGlobPattern("file?.go"); !ok {
+		t.Error(`splitGlobPattern("file?.go") not analyzable, want it to be`)
+	}
+	if _, ok := splitGlobPattern("src/a*b?c/*.go"); ok {
+		t.Error(`splitGlobPattern("src/a*b?c/*.go") analyzable, want it to bail out (two wildcard kinds in one segment)`)
+	}
+	if _, ok := splitGlobPattern("src/[abc/*.go"); ok {
+		t.Error(`splitGlobPattern("src/[abc/*.go") analyzable, want it to bail out (unterminated class)`)
+	}
+}